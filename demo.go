@@ -10,9 +10,9 @@ import (
 	"time"
 
 	"github.com/gammazero/naam-demo/hacknaam"
+	"github.com/gammazero/naam-demo/naam"
 	"github.com/ipfs/boxo/path"
 	"github.com/ipfs/go-cid"
-	"github.com/ipni/go-naam"
 	"github.com/libp2p/go-libp2p/core/peer"
 )
 
@@ -44,7 +44,6 @@ func run(ctx context.Context) error {
 	nm, err := naam.New(
 		naam.WithListenAddr(httpListenAddr),
 		naam.WithAnnounceURL(announceURL),
-		naam.WithFindURL(findURL),
 		naam.WithPublisherAddrs(publisherAddr),
 		naam.WithProviderAddrs(providerAddr),
 	)
@@ -66,30 +65,23 @@ func run(ctx context.Context) error {
 
 	pause("publish IPNS record with target CID", "This creates an advertisement that is ingested by IPNI")
 
-	// Publish IPNS record to IPNI indexer.
-	err = nm.Publish(ctx, publishedPath, naam.WithEOL(time.Now().Add(48*time.Hour)))
+	// Publish IPNS record to IPNI indexer, using the Naam instance's own
+	// host key (the default name when name is "").
+	err = nm.Publish(ctx, "", publishedPath, naam.WithEOL(time.Now().Add(48*time.Hour)))
 	if err != nil {
 		return fmt.Errorf("failed to publish ipns record to ipni: %s", err)
 	}
 	fmt.Println("IPNS record published with name", ipnsName)
 
-	/*
-		// Resolve locally - avoids indexer lookup if naam instance is the publisher.
-		resolvedPath, err := nm.Resolve(ctx, ipnsName)
-		if err != nil {
-			return fmt.Errorf("failed to locally resolve ipns name: %s", err)
-		}
-		fmt.Println("Resolved IPNS record locally:", ipnsName, "==>", resolvedPath)
-	*/
-
-	pause("resolve IPNS name to IPNS record, with reader privacy enabled",
-		"This queries IPNI using the IPNS name to lookup the IPNS record. Reader privacy means IPNI is queried using a hash of the IPNS name multihash, and gets back an encrypted response that can be decrypted with the orignal name multihash. This prevents the indexer from knowing what IPNS names a client is resolving.")
+	pause("resolve IPNS name to IPNS record",
+		"This queries IPNI using the IPNS name to look up every advertisement for it, and validates each candidate record's signature before trusting it.")
 
 retry:
 	start := time.Now()
 
-	// Resolve by looking up IPNS record using indexer with reader-privacy.
-	resolvedPath, err := naam.Resolve(ctx, ipnsName, findURL)
+	// Resolve by looking up and validating IPNS records advertised to the
+	// indexer.
+	resolvedPath, err := naam.ValidatingResolve(ctx, ipnsName, findURL)
 	if err != nil {
 		if errors.Is(err, naam.ErrNotFound) {
 			fmt.Println("Name not found on indexer yet, retrying")
@@ -99,7 +91,7 @@ retry:
 		return fmt.Errorf("failed to resolve ipns name: %s", err)
 	}
 	elapsed := time.Since(start)
-	fmt.Println("🔒 Reader privacy enabled | Resolved IPNS record using indexer:")
+	fmt.Println("🔒 Resolved and validated IPNS record using indexer:")
 	fmt.Println("    Resolved:", ipnsName, "==>", resolvedPath)
 	fmt.Println("    Elapsed:", elapsed)
 
@@ -123,7 +115,7 @@ retry:
 	pause("publish IPNS record with new target CID", "This will replace the previous IPNS record")
 
 	// Publish IPNS record to IPNI indexer.
-	err = nm.Publish(ctx, publishedPath, naam.WithEOL(time.Now().Add(48*time.Hour)))
+	err = nm.Publish(ctx, "", publishedPath, naam.WithEOL(time.Now().Add(48*time.Hour)))
 	if err != nil {
 		return fmt.Errorf("failed to publish ipns record to ipni: %s", err)
 	}
@@ -131,35 +123,22 @@ retry:
 
 	pause("resolve IPNS name to updated IPNS record", "")
 
-	// Resolve by looking up IPNS record using indexer with reader-privacy.
-	resolvedPath, err = naam.Resolve(ctx, ipnsName, findURL)
+	// Resolve by looking up and validating IPNS records advertised to the
+	// indexer.
+	resolvedPath, err = naam.ValidatingResolve(ctx, ipnsName, findURL)
 	if err != nil {
 		if errors.Is(err, naam.ErrNotFound) {
 			fmt.Println("Name not found on indexer yet, retrying")
 		}
 		return fmt.Errorf("failed to resolve ipns name: %s", err)
 	}
-	fmt.Println("🔒 Reader privacy enabled | Resolved IPNS record using indexer:")
+	fmt.Println("🔒 Resolved and validated IPNS record using indexer:")
 	fmt.Println("    Resolved:", ipnsName, "==>", resolvedPath)
 
 	pause("fetch data for the CID that was resolved", "")
 
 	openCIDPath(resolvedPath.String())
 
-	pause("resolve IPNS name to IPNS record, without reader privacy", "")
-
-	start = time.Now()
-
-	// Resolve by looking up IPNS record using indexer without reader-privacy.
-	resolvedPath, err = naam.ResolveNotPrivate(ctx, ipnsName, findURL)
-	if err != nil {
-		return fmt.Errorf("failed to resolve ipns name without reader privacy: %s", err)
-	}
-	elapsed = time.Since(start)
-	fmt.Println("⚠️  Reader privacy disabled | Resolved IPNS record using indexer:")
-	fmt.Println("    Resolved:", ipnsName, "==>", resolvedPath)
-	fmt.Println("    Elapsed:", elapsed)
-
 	pause("try to resolve an IPNS name that is not published", "")
 
 	start = time.Now()
@@ -170,9 +149,9 @@ retry:
 		return err
 	}
 	anotherName := naam.Name(pid)
-	resolvedPath, err = naam.Resolve(ctx, anotherName, findURL)
+	resolvedPath, err = naam.ValidatingResolve(ctx, anotherName, findURL)
 	if !errors.Is(err, naam.ErrNotFound) {
-		fmt.Errorf("resolver: %s", err)
+		fmt.Println("resolver:", err)
 	}
 	elapsed = time.Since(start)
 	fmt.Println("Record for unknown name", anotherName, "not found, as expected")
@@ -188,17 +167,25 @@ retry:
 	}
 
 	pause("resolve the hijacked IPNS name",
-		"The retrieved record should not validate because it was publisher by someone without the private key associated with the IPNS name. In the future if Naam becomes an official protocol, IPNI will recognize IPNS advertisements and validate the record before ingesting it. This will prevent malicious publishers from blocking IPNS lookup with bad records.")
+		"The hijacked record fails signature validation, because it was published by someone without the private key associated with the IPNS name. ValidatingResolve rejects it and reports it as a hijack attempt via *naam.ValidationError, rather than returning it as if it were a legitimate record.")
 
-	// Resolve by looking up IPNS record using indexer with reader-privacy.
-	resolvedPath, err = naam.Resolve(ctx, ipnsName, findURL)
+	// Resolve by looking up and validating IPNS records advertised to the
+	// indexer. The hijacked record should be among the rejected candidates.
+	resolvedPath, err = naam.ValidatingResolve(ctx, ipnsName, findURL)
 	if err != nil {
+		var verr *naam.ValidationError
+		if errors.As(err, &verr) {
+			fmt.Println("🙅 Rejected", len(verr.Rejected), "invalid candidate(s) for", ipnsName+":")
+			for _, rc := range verr.Rejected {
+				fmt.Println("    Provider:", rc.Provider, "Reason:", rc.Reason)
+			}
+		}
 		if errors.Is(err, naam.ErrNotFound) {
 			return fmt.Errorf("name not found on indexer")
 		}
 		fmt.Println("❌ Failed to resolve ipns name:", err)
 	} else {
-		fmt.Println("🙀 🔒 Reader privacy enabled | Resolved IPNS record using indexer:")
+		fmt.Println("🙀 Resolved IPNS record using indexer:")
 		fmt.Println("    Resolved:", ipnsName, "==>", resolvedPath)
 		return fmt.Errorf("hijacked ipns name resolved")
 	}
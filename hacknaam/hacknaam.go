@@ -47,6 +47,11 @@ var (
 
 	headAdCid = datastore.NewKey("headAdCid")
 	height    = datastore.NewKey("height")
+
+	// datastoreKeyPrefix namespaces all keys this package writes, so a
+	// datastore supplied via WithDatastore can safely be shared with other
+	// modules.
+	datastoreKeyPrefix = datastore.NewKey("hacknaam")
 )
 
 type Naam struct {
@@ -57,14 +62,37 @@ type Naam struct {
 	pub           dagsync.Publisher
 }
 
+type config struct {
+	ds datastore.Batching
+}
+
+// Option configures a Naam instance created by New.
+type Option func(*config)
+
+// WithDatastore sets the datastore used to persist the advertisement chain.
+// If not given, New uses an in-memory datastore that does not survive a
+// restart.
+func WithDatastore(ds datastore.Batching) Option {
+	return func(c *config) { c.ds = ds }
+}
+
 // New creates a new Naam instance for publishing IPNS records in an indexer.
-func New(httpListenAddr, httpIndexerURL string) (*Naam, error) {
+func New(httpListenAddr, httpIndexerURL string, opts ...Option) (*Naam, error) {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	h, err := libp2p.New()
 	if err != nil {
 		return nil, err
 	}
 
-	ds := sync.MutexWrap(datastore.NewMapDatastore())
+	ds := cfg.ds
+	if ds == nil {
+		ds = sync.MutexWrap(datastore.NewMapDatastore())
+	}
+	ds = namespace.Wrap(ds, datastoreKeyPrefix)
 	linkSys := makeLinkSys(ds)
 
 	// Create publisher that publishes over http and libptp.
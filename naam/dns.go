@@ -0,0 +1,109 @@
+package naam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/ipfs/boxo/ipns"
+	"github.com/ipfs/boxo/path"
+)
+
+// defaultDNSRecursionLimit bounds how many DNSLink hops ValidatingResolve
+// will follow before giving up, to prevent a DNSLink loop from recursing
+// forever.
+const defaultDNSRecursionLimit = 32
+
+// dnsLinkPrefix is the TXT record prefix identifying a DNSLink value, per
+// https://docs.ipfs.tech/concepts/dnslink/.
+const dnsLinkPrefix = "dnslink="
+
+// dnsLinkCacheTTL is how long a Cache remembers a DNSLink name's resolved
+// path. Go's net.Resolver does not expose the TXT record's own TTL, so this
+// is a fixed duration rather than one derived from DNS.
+const dnsLinkCacheTTL = 5 * time.Minute
+
+// WithDNSResolver overrides the *net.Resolver used for DNSLink lookups,
+// primarily so tests can inject a resolver that does not hit real DNS.
+func WithDNSResolver(r *net.Resolver) ResolveOption {
+	return func(cfg *resolveConfig) { cfg.dnsResolver = r }
+}
+
+// WithMaxDNSDepth overrides how many DNSLink hops ValidatingResolve will
+// follow before giving up. The default is defaultDNSRecursionLimit.
+func WithMaxDNSDepth(depth int) ResolveOption {
+	return func(cfg *resolveConfig) { cfg.dnsMaxDepth = depth }
+}
+
+// withDNSDepth is an internal option used by ValidatingResolve to track the
+// current recursion depth across DNSLink hops.
+func withDNSDepth(depth int) ResolveOption {
+	return func(cfg *resolveConfig) { cfg.dnsDepth = depth }
+}
+
+// resolveDNSLink resolves name, which does not parse as an "/ipns/<peerID>"
+// name, as a DNSLink domain: it queries TXT records on "_dnslink.<domain>"
+// and recurses into ValidatingResolve on the target, so a DNSLink may point
+// at either an /ipfs/ path or another /ipns/ name.
+//
+// As with the indexer-backed lookup, a successful resolution (or an
+// ErrNotFound) is cached under name itself when cfg.cache is set, so that
+// resolving the same DNSLink name again does not require a fresh TXT
+// lookup.
+func resolveDNSLink(ctx context.Context, name, findURL string, cfg resolveConfig, opts []ResolveOption) (path.Path, error) {
+	maxDepth := cfg.dnsMaxDepth
+	if maxDepth == 0 {
+		maxDepth = defaultDNSRecursionLimit
+	}
+	if cfg.dnsDepth >= maxDepth {
+		return path.Path{}, fmt.Errorf("dnslink: recursion limit of %d exceeded resolving %s", maxDepth, name)
+	}
+
+	resolver := cfg.dnsResolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	domain := strings.TrimPrefix(name, ipns.NamespacePrefix)
+	txts, err := resolver.LookupTXT(ctx, "_dnslink."+domain)
+	if err != nil {
+		return path.Path{}, fmt.Errorf("dnslink lookup for %s: %w", domain, err)
+	}
+
+	for _, txt := range txts {
+		val := strings.TrimPrefix(txt, dnsLinkPrefix)
+		if val == txt {
+			// Not a dnslink= record; ignore along with any other TXT
+			// records the domain happens to publish.
+			continue
+		}
+		switch {
+		case strings.HasPrefix(val, "/ipfs/"):
+			value, err := path.NewPath(val)
+			if err != nil {
+				return path.Path{}, err
+			}
+			if cfg.cache != nil {
+				cfg.cache.putValue(name, value, time.Now().Add(dnsLinkCacheTTL))
+			}
+			return value, nil
+		case strings.HasPrefix(val, ipns.NamespacePrefix):
+			nextOpts := append(append([]ResolveOption{}, opts...), withDNSDepth(cfg.dnsDepth+1))
+			value, err := ValidatingResolve(ctx, val, findURL, nextOpts...)
+			if cfg.cache != nil {
+				switch {
+				case err == nil:
+					cfg.cache.putValue(name, value, time.Now().Add(dnsLinkCacheTTL))
+				case errors.Is(err, ErrNotFound):
+					cfg.cache.putNotFound(name)
+				}
+			}
+			return value, err
+		}
+	}
+
+	return path.Path{}, fmt.Errorf("no dnslink record found for %s", domain)
+}
@@ -0,0 +1,28 @@
+// Package naam is a hardened, in-repo take on github.com/ipni/go-naam: it
+// publishes and resolves IPNS records through an IPNI indexer instead of the
+// libp2p DHT. Unlike the upstream package, the resolver in this package
+// verifies record signatures before trusting a result, closing the hijack
+// demonstrated by the hacknaam package.
+package naam
+
+import (
+	"strings"
+
+	"github.com/ipfs/boxo/ipns"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Name returns the IPNS name for a peer ID, e.g. "/ipns/<peerID>".
+func Name(peerID peer.ID) string {
+	return ipns.NamespacePrefix + peerID.String()
+}
+
+// peerIDFromName extracts the peer ID from an "/ipns/<peerID>" name. It
+// returns ipns.ErrInvalidName if name does not have the expected prefix.
+func peerIDFromName(name string) (peer.ID, error) {
+	spid := strings.TrimPrefix(name, ipns.NamespacePrefix)
+	if spid == name {
+		return "", ipns.ErrInvalidName
+	}
+	return peer.Decode(spid)
+}
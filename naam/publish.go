@@ -0,0 +1,509 @@
+package naam
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ipfs/boxo/ipns"
+	"github.com/ipfs/boxo/path"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/ipld/go-ipld-prime"
+	_ "github.com/ipld/go-ipld-prime/codec/dagcbor"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/linking"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/ipni/go-libipni/announce"
+	"github.com/ipni/go-libipni/announce/httpsender"
+	"github.com/ipni/go-libipni/dagsync"
+	"github.com/ipni/go-libipni/dagsync/ipnisync"
+	"github.com/ipni/go-libipni/ingest/schema"
+	"github.com/libp2p/go-libp2p"
+	ic "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multicodec"
+	"github.com/multiformats/go-multihash"
+	"github.com/multiformats/go-varint"
+)
+
+// ContextID is the advertisement context ID used for all naam IPNS
+// advertisements.
+const ContextID = "naam"
+
+var (
+	lp = cidlink.LinkPrototype{
+		Prefix: cid.Prefix{
+			Version:  1,
+			Codec:    uint64(multicodec.DagJson),
+			MhType:   uint64(multicodec.Sha2_256),
+			MhLength: -1,
+		},
+	}
+)
+
+// nameKey returns the datastore key under which pid's advertisement chain
+// (headAdCid, height, published) is namespaced, so one datastore can hold
+// the state for every name a Naam instance publishes.
+func nameKey(pid peer.ID) datastore.Key {
+	return datastore.NewKey(pid.String())
+}
+
+func headAdCidKey(pid peer.ID) datastore.Key { return nameKey(pid).ChildString("headAdCid") }
+func heightKey(pid peer.ID) datastore.Key    { return nameKey(pid).ChildString("height") }
+func publishedKey(pid peer.ID) datastore.Key { return nameKey(pid).ChildString("published") }
+
+// Naam publishes IPNS records as advertisements to an IPNI indexer. It owns
+// a keystore of (name, private key) pairs, each with its own advertisement
+// chain, but shares one libp2p host, HTTP listener, and announce sender
+// across all of them.
+type Naam struct {
+	h             host.Host
+	ds            datastore.Datastore
+	ls            *ipld.LinkSystem
+	pub           dagsync.Publisher
+	httpAnnouncer *httpsender.Sender
+
+	mu   sync.Mutex
+	keys map[peer.ID]ic.PrivKey
+}
+
+// datastoreKeyPrefix namespaces all keys this package writes (headAdCid,
+// height, published, and the linksystem-backed blocks), so a datastore
+// supplied via WithDatastore can safely be shared with other modules.
+var datastoreKeyPrefix = datastore.NewKey("naam")
+
+type config struct {
+	listenAddr     string
+	announceURL    string
+	publisherAddrs []string
+	providerAddrs  []string
+	ds             datastore.Batching
+}
+
+// Option configures a Naam instance created by New.
+type Option func(*config)
+
+// WithDatastore sets the datastore used to persist the advertisement chain
+// (head CID, height, published record, and linksystem blocks). If not
+// given, New uses an in-memory datastore that does not survive a restart.
+func WithDatastore(ds datastore.Batching) Option {
+	return func(c *config) { c.ds = ds }
+}
+
+// WithListenAddr sets the address:port that the advertisement publisher's
+// HTTP listener binds to.
+func WithListenAddr(addr string) Option {
+	return func(c *config) { c.listenAddr = addr }
+}
+
+// WithAnnounceURL sets the indexer ingest URL that new advertisements are
+// announced to.
+func WithAnnounceURL(url string) Option {
+	return func(c *config) { c.announceURL = url }
+}
+
+// WithPublisherAddrs records the multiaddrs to advertise to the indexer as
+// where to fetch advertisements from. The value is stored but not used:
+// announcements carry the publisher's own listen addresses instead.
+func WithPublisherAddrs(addrs ...string) Option {
+	return func(c *config) { c.publisherAddrs = addrs }
+}
+
+// WithProviderAddrs records the multiaddrs to advertise as where to retrieve
+// provided content from. The value is stored but not used: an
+// advertisement's Addresses are the publisher's own listen addresses.
+func WithProviderAddrs(addrs ...string) Option {
+	return func(c *config) { c.providerAddrs = addrs }
+}
+
+// New creates a new Naam instance for publishing IPNS records to an
+// indexer.
+func New(opts ...Option) (*Naam, error) {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	h, err := libp2p.New()
+	if err != nil {
+		return nil, err
+	}
+
+	ds := cfg.ds
+	if ds == nil {
+		ds = dssync.MutexWrap(datastore.NewMapDatastore())
+	}
+	ds = namespace.Wrap(ds, datastoreKeyPrefix)
+	linkSys := makeLinkSys(ds)
+
+	pk := h.Peerstore().PrivKey(h.ID())
+	pub, err := ipnisync.NewPublisher(*linkSys, pk,
+		ipnisync.WithHTTPListenAddrs(cfg.listenAddr),
+		ipnisync.WithStreamHost(h),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	indexerURL, err := url.Parse(cfg.announceURL)
+	if err != nil {
+		return nil, err
+	}
+	httpAnnouncer, err := httpsender.New([]*url.URL{indexerURL}, h.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Naam{
+		h:             h,
+		ds:            ds,
+		ls:            linkSys,
+		pub:           pub,
+		httpAnnouncer: httpAnnouncer,
+		keys: map[peer.ID]ic.PrivKey{
+			h.ID(): h.Peerstore().PrivKey(h.ID()),
+		},
+	}, nil
+}
+
+func makeLinkSys(ds datastore.Datastore) *ipld.LinkSystem {
+	linkSys := cidlink.DefaultLinkSystem()
+	ds = namespace.Wrap(ds, datastore.NewKey("ls"))
+	linkSys.StorageReadOpener = func(ctx linking.LinkContext, l datamodel.Link) (io.Reader, error) {
+		val, err := ds.Get(ctx.Ctx, datastore.NewKey(l.Binary()))
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewBuffer(val), nil
+	}
+	linkSys.StorageWriteOpener = func(ctx ipld.LinkContext) (io.Writer, ipld.BlockWriteCommitter, error) {
+		buf := bytes.NewBuffer(nil)
+		return buf, func(l ipld.Link) error {
+			return ds.Put(ctx.Ctx, datastore.NewKey(l.Binary()), buf.Bytes())
+		}, nil
+	}
+	return &linkSys
+}
+
+// Name returns the IPNS name derived from this Naam instance's host key,
+// the name Publish uses when called without an explicit name.
+func (n *Naam) Name() string {
+	return Name(n.h.ID())
+}
+
+// ImportKey adds priv to the keystore, so it can be published to by name
+// via Publish, and returns the peer ID (and thus the IPNS name) it
+// corresponds to.
+func (n *Naam) ImportKey(priv ic.PrivKey) (peer.ID, error) {
+	pid, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		return "", err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.keys[pid] = priv
+	return pid, nil
+}
+
+// GenerateKey creates a new Ed25519 key, adds it to the keystore, and
+// returns the peer ID (and thus the IPNS name) it corresponds to.
+func (n *Naam) GenerateKey() (peer.ID, error) {
+	priv, _, err := ic.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	return n.ImportKey(priv)
+}
+
+// Names returns the IPNS names this Naam instance can publish to, i.e. the
+// names of every key in its keystore, sorted.
+func (n *Naam) Names() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	names := make([]string, 0, len(n.keys))
+	for pid := range n.keys {
+		names = append(names, Name(pid))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// keyForName returns the peer ID and private key to publish as for name. An
+// empty name defaults to this Naam instance's host key, for compatibility
+// with single-name use.
+func (n *Naam) keyForName(name string) (peer.ID, ic.PrivKey, error) {
+	pid := n.h.ID()
+	if name != "" {
+		var err error
+		pid, err = peerIDFromName(name)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	pk, ok := n.keys[pid]
+	if !ok {
+		return "", nil, fmt.Errorf("naam: no key imported for name %s", Name(pid))
+	}
+	return pid, pk, nil
+}
+
+type publishConfig struct {
+	eol         time.Time
+	noRepublish bool
+}
+
+// PublishOption configures a single Publish call.
+type PublishOption func(*publishConfig)
+
+// WithEOL sets the end-of-life time of the published IPNS record. The
+// default is 24 hours from now.
+func WithEOL(eol time.Time) PublishOption {
+	return func(c *publishConfig) { c.eol = eol }
+}
+
+// WithNoRepublish excludes this name from automatic republishing by a
+// Republisher attached to the same Naam instance.
+func WithNoRepublish() PublishOption {
+	return func(c *publishConfig) { c.noRepublish = true }
+}
+
+// Publish creates and announces a new IPNS record advertisement for name,
+// pointing to value, using the next sequence number in that name's
+// advertisement chain. An empty name defaults to this Naam instance's host
+// key, for compatibility with single-name use. The advertisement's Provider
+// is name's own peer ID, and is signed with name's own private key, not the
+// host key, so the host can publish on behalf of any key it holds.
+func (n *Naam) Publish(ctx context.Context, name string, value path.Path, opts ...PublishOption) error {
+	cfg := publishConfig{eol: time.Now().Add(24 * time.Hour)}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	pid, pk, err := n.keyForName(name)
+	if err != nil {
+		return err
+	}
+
+	prevHeight, err := n.previousHeight(ctx, pid)
+	if err != nil {
+		return err
+	}
+
+	if err := n.publish(ctx, pid, pk, value, prevHeight+1, cfg.eol); err != nil {
+		return err
+	}
+	if !cfg.noRepublish {
+		return n.setPublished(ctx, pid, value, prevHeight+1, cfg.eol)
+	}
+	// Retire any published record left over from an earlier call on this
+	// name, so a Republisher sweep does not read a stale (value, EOL) and
+	// republish it over this, newer, opted-out value.
+	return n.clearPublished(ctx, pid)
+}
+
+// publish runs the advertisement pipeline for an explicit sequence number
+// and EOL. It is factored out of Publish so that a Republisher can
+// re-publish an existing record without a caller supplying a new value.
+func (n *Naam) publish(ctx context.Context, pid peer.ID, pk ic.PrivKey, value path.Path, seq uint64, eol time.Time) error {
+	var prevLink ipld.Link
+	head, err := n.getHeadAdCid(ctx, pid)
+	if err != nil {
+		return err
+	}
+	if head != cid.Undef {
+		prevLink = cidlink.Link{Cid: head}
+	}
+
+	var ttl time.Duration
+	ipnsRec, err := ipns.NewRecord(pk, value, seq, eol, ttl, ipns.WithPublicKey(true))
+	if err != nil {
+		return err
+	}
+
+	mh, err := multihash.Sum([]byte(pid), multihash.SHA2_256, -1)
+	if err != nil {
+		return err
+	}
+	chunk := schema.EntryChunk{
+		Entries: []multihash.Multihash{mh},
+	}
+	cn, err := chunk.ToNode()
+	if err != nil {
+		return err
+	}
+	entriesLink, err := n.ls.Store(ipld.LinkContext{Ctx: ctx}, lp, cn)
+	if err != nil {
+		return err
+	}
+
+	metadata, err := ipnsMetadata(ipnsRec)
+	if err != nil {
+		return err
+	}
+	ad := schema.Advertisement{
+		PreviousID: prevLink,
+		Provider:   pid.String(),
+		Addresses:  n.adAddrs(),
+		Entries:    entriesLink,
+		ContextID:  ContextID,
+		Metadata:   metadata,
+	}
+	if err := ad.Sign(pk); err != nil {
+		return err
+	}
+
+	adn, err := ad.ToNode()
+	if err != nil {
+		return err
+	}
+	adLink, err := n.ls.Store(ipld.LinkContext{Ctx: ctx}, lp, adn)
+	if err != nil {
+		return err
+	}
+
+	newHead := adLink.(cidlink.Link).Cid
+	// The shared publisher only tracks a single sync head, so publishing
+	// under one name moves it forward even though each name has its own
+	// advertisement chain in the datastore; every head is still announced
+	// to the indexer independently via announce.Send below.
+	n.pub.SetRoot(newHead)
+	if err := n.setHeadAdCid(ctx, pid, newHead, seq); err != nil {
+		return err
+	}
+
+	if err := announce.Send(ctx, newHead, n.pub.Addrs(), n.httpAnnouncer); err != nil {
+		return fmt.Errorf("unsuccessful announce: %w", err)
+	}
+	return nil
+}
+
+func (n *Naam) adAddrs() []string {
+	pa := n.pub.Addrs()
+	adAddrs := make([]string, 0, len(pa))
+	for _, a := range pa {
+		adAddrs = append(adAddrs, a.String())
+	}
+	return adAddrs
+}
+
+func (n *Naam) setHeadAdCid(ctx context.Context, pid peer.ID, head cid.Cid, seq uint64) error {
+	if err := n.ds.Put(ctx, headAdCidKey(pid), head.Bytes()); err != nil {
+		return err
+	}
+	return n.ds.Put(ctx, heightKey(pid), varint.ToUvarint(seq))
+}
+
+func (n *Naam) previousHeight(ctx context.Context, pid peer.ID) (uint64, error) {
+	v, err := n.ds.Get(ctx, heightKey(pid))
+	if err != nil {
+		if err == datastore.ErrNotFound {
+			return 0, nil
+		}
+		return 0, err
+	}
+	buf := bytes.NewBuffer(v)
+	return varint.ReadUvarint(buf)
+}
+
+func (n *Naam) getHeadAdCid(ctx context.Context, pid peer.ID) (cid.Cid, error) {
+	c, err := n.ds.Get(ctx, headAdCidKey(pid))
+	if err != nil {
+		if err == datastore.ErrNotFound {
+			return cid.Undef, nil
+		}
+		return cid.Undef, err
+	}
+	_, head, err := cid.CidFromBytes(c)
+	if err != nil {
+		return cid.Undef, nil
+	}
+	return head, nil
+}
+
+// setPublished records the value, sequence, and EOL of the most recently
+// published record, so that a Republisher can refresh it later without the
+// caller supplying the value again.
+func (n *Naam) setPublished(ctx context.Context, pid peer.ID, value path.Path, seq uint64, eol time.Time) error {
+	var buf bytes.Buffer
+	valBytes := []byte(value.String())
+	buf.Write(varint.ToUvarint(uint64(len(valBytes))))
+	buf.Write(valBytes)
+	buf.Write(varint.ToUvarint(seq))
+	eolBytes, err := eol.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	buf.Write(varint.ToUvarint(uint64(len(eolBytes))))
+	buf.Write(eolBytes)
+	return n.ds.Put(ctx, publishedKey(pid), buf.Bytes())
+}
+
+// clearPublished removes any published record recorded for pid, so that a
+// Republisher sweep sees it the same as a name that has never been
+// published: nothing to refresh.
+func (n *Naam) clearPublished(ctx context.Context, pid peer.ID) error {
+	return n.ds.Delete(ctx, publishedKey(pid))
+}
+
+// getPublished returns the value, sequence, and EOL recorded by the last
+// call to setPublished for pid. It returns datastore.ErrNotFound if nothing
+// has been published for pid, or it was published with WithNoRepublish.
+func (n *Naam) getPublished(ctx context.Context, pid peer.ID) (path.Path, uint64, time.Time, error) {
+	v, err := n.ds.Get(ctx, publishedKey(pid))
+	if err != nil {
+		return path.Path{}, 0, time.Time{}, err
+	}
+	buf := bytes.NewReader(v)
+	valLen, err := varint.ReadUvarint(buf)
+	if err != nil {
+		return path.Path{}, 0, time.Time{}, err
+	}
+	valBytes := make([]byte, valLen)
+	if _, err := io.ReadFull(buf, valBytes); err != nil {
+		return path.Path{}, 0, time.Time{}, err
+	}
+	value, err := path.NewPath(string(valBytes))
+	if err != nil {
+		return path.Path{}, 0, time.Time{}, err
+	}
+	seq, err := varint.ReadUvarint(buf)
+	if err != nil {
+		return path.Path{}, 0, time.Time{}, err
+	}
+	eolLen, err := varint.ReadUvarint(buf)
+	if err != nil {
+		return path.Path{}, 0, time.Time{}, err
+	}
+	eolBytes := make([]byte, eolLen)
+	if _, err := io.ReadFull(buf, eolBytes); err != nil {
+		return path.Path{}, 0, time.Time{}, err
+	}
+	var eol time.Time
+	if err := eol.UnmarshalBinary(eolBytes); err != nil {
+		return path.Path{}, 0, time.Time{}, err
+	}
+	return value, seq, eol, nil
+}
+
+func ipnsMetadata(rec *ipns.Record) ([]byte, error) {
+	var metadata bytes.Buffer
+	metadata.Write(varint.ToUvarint(uint64(MetadataProtocolID)))
+	marshal, err := ipns.MarshalRecord(rec)
+	if err != nil {
+		return nil, err
+	}
+	metadata.Write(marshal)
+	return metadata.Bytes(), nil
+}
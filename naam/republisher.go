@@ -0,0 +1,151 @@
+package naam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+)
+
+// RepublisherConfig holds the tunables for a Republisher.
+type RepublisherConfig struct {
+	// RecordLifetime is the EOL duration given to a republished record,
+	// measured from the time it is republished.
+	RecordLifetime time.Duration
+	// RepublishInterval is how often the Republisher checks whether the
+	// published record needs refreshing.
+	RepublishInterval time.Duration
+	// RepublishWindow is how far ahead of a record's EOL the Republisher
+	// will refresh it.
+	RepublishWindow time.Duration
+}
+
+// defaultRepublisherConfig matches the defaults used by boxo's
+// namesys/republisher for republishing DHT IPNS records.
+func defaultRepublisherConfig() RepublisherConfig {
+	return RepublisherConfig{
+		RecordLifetime:    24 * time.Hour,
+		RepublishInterval: time.Hour,
+		RepublishWindow:   4 * time.Hour,
+	}
+}
+
+// RepublisherOption configures a Republisher created by NewRepublisher.
+type RepublisherOption func(*RepublisherConfig)
+
+// WithRecordLifetime sets the EOL duration given to a republished record.
+func WithRecordLifetime(d time.Duration) RepublisherOption {
+	return func(c *RepublisherConfig) { c.RecordLifetime = d }
+}
+
+// WithRepublishInterval sets how often the Republisher checks whether the
+// published record needs refreshing.
+func WithRepublishInterval(d time.Duration) RepublisherOption {
+	return func(c *RepublisherConfig) { c.RepublishInterval = d }
+}
+
+// WithRepublishWindow sets how far ahead of a record's EOL the Republisher
+// will refresh it.
+func WithRepublishWindow(d time.Duration) RepublisherOption {
+	return func(c *RepublisherConfig) { c.RepublishWindow = d }
+}
+
+// Republisher periodically refreshes the IPNS record published by a Naam
+// instance before it expires, analogous to boxo's namesys/republisher.
+type Republisher struct {
+	n      *Naam
+	cfg    RepublisherConfig
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewRepublisher creates a Republisher for n. Call Start to begin the
+// background refresh loop.
+func NewRepublisher(n *Naam, opts ...RepublisherOption) *Republisher {
+	cfg := defaultRepublisherConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &Republisher{n: n, cfg: cfg}
+}
+
+// Start launches the background goroutine that refreshes n's published
+// record whenever its EOL falls within RepublishWindow. Start is a no-op if
+// the Republisher is already running.
+func (r *Republisher) Start() {
+	if r.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = make(chan struct{})
+	go r.run(ctx)
+}
+
+// Stop ends the background refresh loop and waits for it to exit.
+func (r *Republisher) Stop() {
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	<-r.done
+	r.cancel = nil
+}
+
+func (r *Republisher) run(ctx context.Context) {
+	defer close(r.done)
+	ticker := time.NewTicker(r.cfg.RepublishInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.republishIfDue(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// republishIfDue re-publishes every name in n's keystore whose current EOL
+// falls within the RepublishWindow, with an incremented sequence number and
+// an extended EOL. It skips names that have nothing published, or whose
+// published record opted out via WithNoRepublish. A name whose republish
+// fails does not stop the others in the keystore from being refreshed; all
+// errors are joined and returned once the sweep is complete.
+func (r *Republisher) republishIfDue(ctx context.Context) error {
+	var errs []error
+	for _, name := range r.n.Names() {
+		if err := r.republishNameIfDue(ctx, name); err != nil {
+			errs = append(errs, fmt.Errorf("republishing %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Republisher) republishNameIfDue(ctx context.Context, name string) error {
+	pid, err := peerIDFromName(name)
+	if err != nil {
+		return err
+	}
+
+	value, _, eol, err := r.n.getPublished(ctx, pid)
+	if err != nil {
+		if err == datastore.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+
+	if time.Until(eol) > r.cfg.RepublishWindow {
+		return nil
+	}
+
+	newEOL := time.Now().Add(r.cfg.RecordLifetime)
+	if eol.After(newEOL) {
+		newEOL = eol
+	}
+
+	return r.n.Publish(ctx, name, value, WithEOL(newEOL))
+}
@@ -0,0 +1,327 @@
+package naam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2"
+	"github.com/ipfs/boxo/ipns"
+	"github.com/ipfs/boxo/path"
+	httpfind "github.com/ipni/go-libipni/find/client/http"
+	"github.com/ipni/go-libipni/find/model"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multihash"
+	"github.com/multiformats/go-varint"
+)
+
+// negativeCacheTTL is how long a Cache remembers that a name resolved to
+// ErrNotFound, to avoid hammering the indexer while waiting for a record
+// that was just published to be ingested.
+const negativeCacheTTL = 5 * time.Second
+
+// positiveCacheTTL is how long a Cache serves a resolved value before
+// revalidating against the indexer, capped by the record's own EOL. This
+// bounds how stale a cached value can be after a legitimate republish,
+// analogous to boxo's namesys_cache.go revalidating well before a record's
+// much longer EOL.
+const positiveCacheTTL = time.Minute
+
+// defaultCacheSize is the number of names a Cache created with NewCache
+// remembers.
+const defaultCacheSize = 256
+
+// MetadataProtocolID identifies naam's IPNS-record metadata within an
+// advertisement's opaque Metadata field.
+const MetadataProtocolID = 0x300000
+
+// ErrNotFound is returned when no valid IPNS record exists for a name.
+var ErrNotFound = errors.New("ipns record not found")
+
+// RejectedCandidate describes an advertisement that claimed to resolve a
+// name but was discarded during validation, along with the reason why.
+type RejectedCandidate struct {
+	Provider peer.ID
+	Reason   error
+}
+
+// ValidationError is returned alongside a successful resolution when one or
+// more candidate advertisements for the name failed validation. Callers can
+// inspect Rejected to log hijack attempts against the name.
+//
+// When every candidate was rejected and no record could be resolved,
+// NotFound is true and Unwrap returns ErrNotFound, so callers using the
+// errors.Is(err, naam.ErrNotFound) idiom still detect that case.
+type ValidationError struct {
+	Rejected []RejectedCandidate
+	NotFound bool
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("naam: %d invalid ipns record candidate(s) rejected", len(e.Rejected))
+}
+
+func (e *ValidationError) Unwrap() error {
+	if e.NotFound {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// cacheEntry holds a cached resolution result along with when it expires.
+type cacheEntry struct {
+	value   path.Path
+	err     error
+	expires time.Time
+}
+
+// Cache is a TTL-aware LRU cache of resolved IPNS names, modeled on boxo's
+// namesys_cache.go. A positive entry expires after positiveCacheTTL, capped
+// by the resolved record's own EOL if that comes sooner, so a name that is
+// republished with a new value under the same EOL window is not served
+// stale for the rest of that window; a negative (ErrNotFound) entry expires
+// after negativeCacheTTL, so a name that was just published is not treated
+// as permanently missing.
+type Cache struct {
+	mu  sync.Mutex
+	lru *lru.Cache[string, cacheEntry]
+}
+
+// NewCache creates a Cache holding up to size resolved names. A size of 0
+// uses defaultCacheSize.
+func NewCache(size int) *Cache {
+	if size <= 0 {
+		size = defaultCacheSize
+	}
+	l, _ := lru.New[string, cacheEntry](size)
+	return &Cache{lru: l}
+}
+
+func (c *Cache) get(name string) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.lru.Get(name)
+	if !ok {
+		return cacheEntry{}, false
+	}
+	if time.Now().After(e.expires) {
+		c.lru.Remove(name)
+		return cacheEntry{}, false
+	}
+	return e, true
+}
+
+func (c *Cache) putValue(name string, value path.Path, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lru.Add(name, cacheEntry{value: value, expires: expires})
+}
+
+func (c *Cache) putNotFound(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lru.Add(name, cacheEntry{err: ErrNotFound, expires: time.Now().Add(negativeCacheTTL)})
+}
+
+type resolveConfig struct {
+	cache *Cache
+
+	// dnsResolver, dnsMaxDepth, and dnsDepth support the DNSLink fallback
+	// in dns.go: when name does not parse as an /ipns/<peerID> name,
+	// ValidatingResolve falls back to a DNSLink lookup.
+	dnsResolver *net.Resolver
+	dnsMaxDepth int
+	dnsDepth    int
+}
+
+// ResolveOption configures a ValidatingResolve call.
+type ResolveOption func(*resolveConfig)
+
+// WithCache makes ValidatingResolve consult and populate c instead of
+// querying the indexer on every call.
+func WithCache(c *Cache) ResolveOption {
+	return func(cfg *resolveConfig) { cfg.cache = c }
+}
+
+// ValidatingResolve resolves name by fetching every advertisement the
+// indexer at findURL has for the name's multihash, verifying each
+// candidate's embedded IPNS record, and returning the path from the
+// surviving record with the highest Sequence, breaking ties in favor of the
+// later EOL. A candidate is rejected if its provider peer ID does not match
+// the queried name, its record signature does not verify, its EOL has
+// passed, or its embedded public key does not hash to the queried peer ID.
+//
+// If at least one candidate was rejected, ValidatingResolve returns a
+// *ValidationError alongside the resolved path (or alone, wrapping
+// ErrNotFound, if no candidate survived), so callers can detect and log
+// hijack attempts.
+//
+// With WithCache, a clean result (no rejected candidates) is cached for
+// positiveCacheTTL (capped by the resolved record's EOL, if sooner), and
+// ErrNotFound, including the case where every candidate was rejected, is
+// cached for negativeCacheTTL, so repeated calls do not hammer the indexer.
+//
+// If name does not parse as an "/ipns/<peerID>" name, ValidatingResolve
+// falls back to a DNSLink lookup (see dns.go), so human-readable names like
+// "/ipns/example.com" resolve against the same indexer-backed IPNS lookup.
+func ValidatingResolve(ctx context.Context, name, findURL string, opts ...ResolveOption) (path.Path, error) {
+	var cfg resolveConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.cache != nil {
+		if e, ok := cfg.cache.get(name); ok {
+			return e.value, e.err
+		}
+	}
+
+	pid, err := peerIDFromName(name)
+	if err != nil {
+		return resolveDNSLink(ctx, name, findURL, cfg, opts)
+	}
+
+	mh, err := multihash.Sum([]byte(pid), multihash.SHA2_256, -1)
+	if err != nil {
+		return path.Path{}, err
+	}
+
+	cl, err := httpfind.New(findURL)
+	if err != nil {
+		return path.Path{}, err
+	}
+	resp, err := cl.Find(ctx, mh)
+	if err != nil {
+		return path.Path{}, err
+	}
+	if resp == nil || len(resp.MultihashResults) == 0 {
+		if cfg.cache != nil {
+			cfg.cache.putNotFound(name)
+		}
+		return path.Path{}, ErrNotFound
+	}
+
+	var best *ipns.Record
+	var rejected []RejectedCandidate
+
+	for _, mhr := range resp.MultihashResults {
+		for _, pr := range mhr.ProviderResults {
+			rec, provID, err := decodeAndVerifyRecord(pr, pid)
+			if err != nil {
+				rejected = append(rejected, RejectedCandidate{Provider: provID, Reason: err})
+				continue
+			}
+			if best == nil || recordSupersedes(rec, best) {
+				best = rec
+			}
+		}
+	}
+
+	if best == nil {
+		if len(rejected) > 0 {
+			if cfg.cache != nil {
+				cfg.cache.putNotFound(name)
+			}
+			return path.Path{}, &ValidationError{Rejected: rejected, NotFound: true}
+		}
+		if cfg.cache != nil {
+			cfg.cache.putNotFound(name)
+		}
+		return path.Path{}, ErrNotFound
+	}
+
+	value, err := best.Value()
+	if err != nil {
+		return path.Path{}, err
+	}
+	if len(rejected) > 0 {
+		return value, &ValidationError{Rejected: rejected}
+	}
+	if cfg.cache != nil {
+		if eol, err := best.Validity(); err == nil {
+			expires := time.Now().Add(positiveCacheTTL)
+			if eol.Before(expires) {
+				expires = eol
+			}
+			cfg.cache.putValue(name, value, expires)
+		}
+	}
+	return value, nil
+}
+
+// decodeAndVerifyRecord unmarshals and validates the IPNS record embedded in
+// a single provider result, checking its signature and EOL with boxo's IPNS
+// record validator.
+func decodeAndVerifyRecord(pr model.ProviderResult, wantPID peer.ID) (*ipns.Record, peer.ID, error) {
+	provID := pr.Provider.ID
+	if provID != wantPID {
+		return nil, provID, fmt.Errorf("advertisement provider %s does not match name", provID)
+	}
+
+	protocol, n, err := varint.FromUvarint(pr.Metadata)
+	if err != nil {
+		return nil, provID, fmt.Errorf("invalid advertisement metadata: %w", err)
+	}
+	if protocol != MetadataProtocolID {
+		return nil, provID, fmt.Errorf("advertisement metadata is not a naam ipns record")
+	}
+
+	rec, err := ipns.UnmarshalRecord(pr.Metadata[n:])
+	if err != nil {
+		return nil, provID, fmt.Errorf("invalid ipns record: %w", err)
+	}
+
+	pub, err := rec.PubKey()
+	if err != nil {
+		return nil, provID, fmt.Errorf("ipns record missing public key: %w", err)
+	}
+	pkPeerID, err := peer.IDFromPublicKey(pub)
+	if err != nil {
+		return nil, provID, err
+	}
+	if pkPeerID != wantPID {
+		return nil, provID, fmt.Errorf("embedded public key does not match name")
+	}
+
+	if err := ipns.Validate(rec, pub); err != nil {
+		return nil, provID, fmt.Errorf("signature verification failed: %w", err)
+	}
+
+	eol, err := rec.Validity()
+	if err != nil {
+		return nil, provID, err
+	}
+	if time.Now().After(eol) {
+		return nil, provID, fmt.Errorf("ipns record expired at %s", eol)
+	}
+
+	return rec, provID, nil
+}
+
+// recordSupersedes reports whether candidate should replace current as the
+// best known record: higher Sequence wins, ties broken by the later EOL.
+func recordSupersedes(candidate, current *ipns.Record) bool {
+	cSeq, err := candidate.Sequence()
+	if err != nil {
+		return false
+	}
+	curSeq, err := current.Sequence()
+	if err != nil {
+		return true
+	}
+	if cSeq != curSeq {
+		return cSeq > curSeq
+	}
+	cEOL, err := candidate.Validity()
+	if err != nil {
+		return false
+	}
+	curEOL, err := current.Validity()
+	if err != nil {
+		return true
+	}
+	return cEOL.After(curEOL)
+}
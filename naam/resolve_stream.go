@@ -0,0 +1,93 @@
+package naam
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipfs/boxo/path"
+	httpfind "github.com/ipni/go-libipni/find/client/http"
+	"github.com/ipni/go-libipni/find/model"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multihash"
+)
+
+// Result is one candidate IPNS record observed by ResolveStream, or the
+// validation error that caused it to be rejected.
+type Result struct {
+	Path     path.Path
+	Seq      uint64
+	EOL      time.Time
+	Provider peer.ID
+	Err      error
+}
+
+// ResolveStream resolves name against the indexer at findURL like
+// ValidatingResolve, but instead of blocking until a single best answer is
+// chosen, it emits every candidate advertisement's record (or the reason it
+// was rejected) on the returned channel as the indexer's response is
+// processed, sharing the fetch/validate pipeline ValidatingResolve uses.
+// This lets a caller watch a name that is being actively updated rather than
+// only see one point-in-time answer. The channel is closed once every
+// advertisement the indexer returned for name has been processed.
+func ResolveStream(ctx context.Context, name, findURL string) (<-chan Result, error) {
+	pid, err := peerIDFromName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	mh, err := multihash.Sum([]byte(pid), multihash.SHA2_256, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	cl, err := httpfind.New(findURL)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := cl.Find(ctx, mh)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(chan Result)
+	go func() {
+		defer close(results)
+		if resp == nil {
+			return
+		}
+		for _, mhr := range resp.MultihashResults {
+			for _, pr := range mhr.ProviderResults {
+				res := decodeToResult(pr, pid)
+				select {
+				case results <- res:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return results, nil
+}
+
+// decodeToResult runs the same decode-and-verify pipeline as
+// decodeAndVerifyRecord, but reports the outcome as a Result instead of
+// discarding the record on validation failure.
+func decodeToResult(pr model.ProviderResult, pid peer.ID) Result {
+	rec, provID, err := decodeAndVerifyRecord(pr, pid)
+	if err != nil {
+		return Result{Provider: provID, Err: err}
+	}
+	value, err := rec.Value()
+	if err != nil {
+		return Result{Provider: provID, Err: err}
+	}
+	seq, err := rec.Sequence()
+	if err != nil {
+		return Result{Provider: provID, Err: err}
+	}
+	eol, err := rec.Validity()
+	if err != nil {
+		return Result{Provider: provID, Err: err}
+	}
+	return Result{Path: value, Seq: seq, EOL: eol, Provider: provID}
+}
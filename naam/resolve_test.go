@@ -0,0 +1,144 @@
+package naam
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/ipfs/boxo/ipns"
+	"github.com/ipfs/boxo/path"
+	"github.com/ipni/go-libipni/find/model"
+	ic "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// newTestCandidate builds a model.ProviderResult carrying a signed IPNS
+// record for value, the way naam.publish embeds one in an advertisement's
+// Metadata field, along with the peer ID it was signed by.
+func newTestCandidate(t *testing.T, value path.Path, seq uint64, eol time.Time) (model.ProviderResult, peer.ID) {
+	t.Helper()
+	priv, _, err := ic.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := ipns.NewRecord(priv, value, seq, eol, 0, ipns.WithPublicKey(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata, err := ipnsMetadata(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return model.ProviderResult{
+		Provider: peer.AddrInfo{ID: pid},
+		Metadata: metadata,
+	}, pid
+}
+
+func TestDecodeAndVerifyRecordAccepts(t *testing.T) {
+	value, err := path.NewPath("/ipfs/QmPNHBy5h7f19yJDt7ip9TvmMRbqmYsa6aetkrsc1ghjLB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, pid := newTestCandidate(t, value, 1, time.Now().Add(time.Hour))
+
+	rec, provID, err := decodeAndVerifyRecord(pr, pid)
+	if err != nil {
+		t.Fatalf("expected candidate to be accepted, got: %s", err)
+	}
+	if provID != pid {
+		t.Fatalf("provider ID = %s, want %s", provID, pid)
+	}
+	gotValue, err := rec.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotValue.String() != value.String() {
+		t.Fatalf("resolved value = %s, want %s", gotValue, value)
+	}
+}
+
+func TestDecodeAndVerifyRecordRejectsProviderMismatch(t *testing.T) {
+	value, err := path.NewPath("/ipfs/QmPNHBy5h7f19yJDt7ip9TvmMRbqmYsa6aetkrsc1ghjLB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, _ := newTestCandidate(t, value, 1, time.Now().Add(time.Hour))
+
+	_, otherPID := newTestCandidate(t, value, 1, time.Now().Add(time.Hour))
+
+	if _, _, err := decodeAndVerifyRecord(pr, otherPID); err == nil {
+		t.Fatal("expected candidate signed for a different name to be rejected")
+	}
+}
+
+func TestDecodeAndVerifyRecordRejectsBadMetadataProtocol(t *testing.T) {
+	value, err := path.NewPath("/ipfs/QmPNHBy5h7f19yJDt7ip9TvmMRbqmYsa6aetkrsc1ghjLB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, pid := newTestCandidate(t, value, 1, time.Now().Add(time.Hour))
+	pr.Metadata = []byte{0x01, 0x02, 0x03}
+
+	if _, _, err := decodeAndVerifyRecord(pr, pid); err == nil {
+		t.Fatal("expected candidate with non-naam metadata to be rejected")
+	}
+}
+
+func TestDecodeAndVerifyRecordRejectsExpired(t *testing.T) {
+	value, err := path.NewPath("/ipfs/QmPNHBy5h7f19yJDt7ip9TvmMRbqmYsa6aetkrsc1ghjLB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, pid := newTestCandidate(t, value, 1, time.Now().Add(-time.Hour))
+
+	if _, _, err := decodeAndVerifyRecord(pr, pid); err == nil {
+		t.Fatal("expected expired candidate to be rejected")
+	}
+}
+
+func TestRecordSupersedes(t *testing.T) {
+	value, err := path.NewPath("/ipfs/QmPNHBy5h7f19yJDt7ip9TvmMRbqmYsa6aetkrsc1ghjLB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, _, err := ic.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	lowSeq, err := ipns.NewRecord(priv, value, 1, now.Add(time.Hour), 0, ipns.WithPublicKey(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	highSeq, err := ipns.NewRecord(priv, value, 2, now.Add(time.Hour), 0, ipns.WithPublicKey(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameSeqEarlierEOL, err := ipns.NewRecord(priv, value, 2, now.Add(time.Minute), 0, ipns.WithPublicKey(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameSeqLaterEOL, err := ipns.NewRecord(priv, value, 2, now.Add(2*time.Hour), 0, ipns.WithPublicKey(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !recordSupersedes(highSeq, lowSeq) {
+		t.Error("higher sequence should supersede lower sequence")
+	}
+	if recordSupersedes(lowSeq, highSeq) {
+		t.Error("lower sequence should not supersede higher sequence")
+	}
+	if recordSupersedes(sameSeqEarlierEOL, sameSeqLaterEOL) {
+		t.Error("same sequence with earlier EOL should not supersede later EOL")
+	}
+	if !recordSupersedes(sameSeqLaterEOL, sameSeqEarlierEOL) {
+		t.Error("same sequence with later EOL should supersede earlier EOL")
+	}
+}